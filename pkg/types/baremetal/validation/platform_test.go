@@ -53,6 +53,19 @@ func TestValidatePlatform(t *testing.T) {
 				IngressVIP("192.168.222.4").build(),
 			expected: "Invalid value: \"192.168.222.4\": IP expected to be in one of the machine networks: 192.168.111.0/24",
 		},
+		{
+			name: "invalid_same_vips",
+			platform: platform().
+				APIVIP("192.168.111.2").
+				IngressVIP("192.168.111.2").build(),
+			expected: "baremetal.ingressVIP: Invalid value: \"192.168.111.2\": IPs for both API and Ingress should not be the same",
+		},
+		{
+			name: "valid_distinct_vips",
+			platform: platform().
+				APIVIP("192.168.111.2").
+				IngressVIP("192.168.111.3").build(),
+		},
 		{
 			name: "invalid_hosts",
 			platform: platform().
@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	operv1 "github.com/openshift/api/operator/v1"
@@ -40,8 +41,32 @@ import (
 
 const (
 	masterPoolName = "master"
+
+	// serviceNetworkBaselineAddresses is the number of service addresses
+	// set aside for the services created by the core cluster operators.
+	// A service network must hold this baseline plus one address per node.
+	serviceNetworkBaselineAddresses = 128
+
+	// clusterNetworkHeadroomNodes is the number of host subnetworks a
+	// multi-node cluster network must have free beyond one per node, so
+	// that the cluster can be scaled out or have machines replaced.
+	clusterNetworkHeadroomNodes = 3
+
+	// machineNetworkInfrastructureAddresses is the number of machine network
+	// addresses needed besides the nodes and VIPs: the bootstrap machine and
+	// the gateway.
+	machineNetworkInfrastructureAddresses = 2
+
+	// largeIPv4NetworkPrefix is the IPv4 prefix length below which cluster and
+	// service networks are considered unusually large.
+	largeIPv4NetworkPrefix = 12
 )
 
+// clusterDomainSANPrefixes are the prefixes of the DNS names derived from the
+// cluster domain that are used as subject alternative names in the cluster's
+// serving certificates.
+var clusterDomainSANPrefixes = []string{"api.", "api-int.", "*.apps."}
+
 // list of known plugins that require hostPrefix to be set
 var pluginsUsingHostPrefix = sets.NewString(string(operv1.NetworkTypeOpenShiftSDN), string(operv1.NetworkTypeOVNKubernetes))
 
@@ -58,9 +83,14 @@ func ValidateInstallConfig(c *types.InstallConfig) field.ErrorList {
 		return field.ErrorList{field.Invalid(field.NewPath("apiVersion"), c.TypeMeta.APIVersion, fmt.Sprintf("install-config version must be %q", types.InstallConfigVersion))}
 	}
 	if c.SSHKey != "" {
-		if err := validate.SSHPublicKey(c.SSHKey); err != nil {
+		if err := validate.NoPrivateKey(c.SSHKey); err != nil {
+			// do not echo the key back, it is a secret
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("sshKey"), err.Error()))
+		} else if err := validate.SSHPublicKey(c.SSHKey); err != nil {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("sshKey"), c.SSHKey, err.Error()))
 		}
+	} else {
+		warnMissingSSHKey(c)
 	}
 	if c.AdditionalTrustBundle != "" {
 		if err := validate.CABundle(c.AdditionalTrustBundle); err != nil {
@@ -86,12 +116,19 @@ func ValidateInstallConfig(c *types.InstallConfig) field.ErrorList {
 		clusterDomain := c.ClusterDomain()
 		if err := validate.DomainName(clusterDomain, true); err != nil {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("baseDomain"), clusterDomain, err.Error()))
+		} else {
+			allErrs = append(allErrs, validateCertificateNames(clusterDomain, field.NewPath("baseDomain"))...)
 		}
 	}
 	if c.Networking != nil {
 		allErrs = append(allErrs, validateNetworking(c.Networking, field.NewPath("networking"))...)
 		allErrs = append(allErrs, validateNetworkingIPVersion(c.Networking, &c.Platform)...)
 		allErrs = append(allErrs, validateNetworkingForPlatform(c.Networking, &c.Platform, field.NewPath("networking"))...)
+		warnLargeNetworks(c.Networking, field.NewPath("networking"))
+		nodes := machineReplicas(c)
+		allErrs = append(allErrs, validateClusterNetworkCapacity(c.Networking, nodes, field.NewPath("networking", "clusterNetwork"))...)
+		allErrs = append(allErrs, validateServiceNetworkCapacity(c.Networking, nodes, field.NewPath("networking", "serviceNetwork"))...)
+		allErrs = append(allErrs, validateMachineNetworkCapacity(c.Networking, &c.Platform, nodes, field.NewPath("networking", "machineNetwork"))...)
 	} else {
 		allErrs = append(allErrs, field.Required(field.NewPath("networking"), "networking is required"))
 	}
@@ -117,6 +154,40 @@ func ValidateInstallConfig(c *types.InstallConfig) field.ErrorList {
 	return allErrs
 }
 
+// validateCertificateNames checks that the certificate names derived from the
+// cluster domain fit within the DNS name length limit, and warns when they come
+// within a label's length of it.
+func validateCertificateNames(clusterDomain string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	longest := ""
+	for _, prefix := range clusterDomainSANPrefixes {
+		if san := prefix + clusterDomain; len(san) > len(longest) {
+			longest = san
+		}
+	}
+	switch {
+	case len(longest) > k8svalidation.DNS1123SubdomainMaxLength:
+		allErrs = append(allErrs, field.Invalid(fldPath, longest, fmt.Sprintf("certificate name derived from the cluster name and base domain must be no more than %d characters", k8svalidation.DNS1123SubdomainMaxLength)))
+	case len(longest) > k8svalidation.DNS1123SubdomainMaxLength-k8svalidation.DNS1123LabelMaxLength:
+		logrus.Warnf("%s: certificate name %q derived from the cluster name and base domain is unusually long", fldPath, longest)
+	}
+	return allErrs
+}
+
+// warnMissingSSHKey warns when no SSH key is provided for a bare metal
+// install, since SSH is then the only way to debug nodes that fail to join
+// the cluster. Single-node clusters only get a softer note.
+func warnMissingSSHKey(c *types.InstallConfig) {
+	if c.Platform.BareMetal == nil && c.Platform.None == nil {
+		return
+	}
+	if c.ControlPlane != nil && c.ControlPlane.Replicas != nil && *c.ControlPlane.Replicas == 1 && machineReplicas(c) == 1 {
+		logrus.Infof("%s: no SSH key provided, the node will only be reachable through its console", field.NewPath("sshKey"))
+		return
+	}
+	logrus.Warnf("%s: no SSH key provided, nodes will not be accessible for debugging a failed install", field.NewPath("sshKey"))
+}
+
 // ipAddressTypeByField is a map of field path to whether they request IPv4 or IPv6.
 type ipAddressTypeByField map[string]struct{ IPv4, IPv6 bool }
 
@@ -188,7 +259,7 @@ func validateNetworkingIPVersion(n *types.Networking, p *types.Platform) field.E
 	switch {
 	case hasIPv4 && hasIPv6:
 		if n.NetworkType == string(operv1.NetworkTypeOpenShiftSDN) {
-			allErrs = append(allErrs, field.Invalid(field.NewPath("networking", "networkType"), n.NetworkType, "dual-stack IPv4/IPv6 is not supported for this networking plugin"))
+			allErrs = append(allErrs, field.Invalid(field.NewPath("networking", "networkType"), n.NetworkType, "OpenShiftSDN does not support dual-stack; use OVNKubernetes"))
 		}
 
 		if len(n.ServiceNetwork) != 2 {
@@ -213,11 +284,26 @@ func validateNetworkingIPVersion(n *types.Networking, p *types.Platform) field.E
 			}
 		}
 
+		// the family of the first entry is the cluster's primary IP family,
+		// and it must be the same for every list
+		if len(addresses["clusterNetwork"]) > 0 {
+			primaryIPv6 := addresses["clusterNetwork"][0].To4() == nil
+			for _, k := range []string{"machineNetwork", "serviceNetwork"} {
+				if v := presence[k]; v.IPv4 && v.IPv6 && (addresses[k][0].To4() == nil) != primaryIPv6 {
+					allErrs = append(allErrs, field.Invalid(field.NewPath("networking", k), strings.Join(ipSliceToStrings(addresses[k]), ", "), "dual-stack IPv4/IPv6 requires the same address family to be listed first in clusterNetwork, serviceNetwork and machineNetwork"))
+				}
+			}
+		}
+
 	case hasIPv6:
 		if n.NetworkType == string(operv1.NetworkTypeOpenShiftSDN) {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("networking", "networkType"), n.NetworkType, "IPv6 is not supported for this networking plugin"))
 		}
 
+		if len(n.ServiceNetwork) > 1 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("networking", "serviceNetwork"), strings.Join(ipnetworksToStrings(n.ServiceNetwork), ", "), "only one service network can be specified"))
+		}
+
 		switch {
 		case p.BareMetal != nil:
 		case p.None != nil:
@@ -268,13 +354,15 @@ func validateNetworking(n *types.Networking, fldPath *field.Path) field.ErrorLis
 		if err := validate.SubnetCIDR(&sn.IPNet); err != nil {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), err.Error()))
 		}
-		for _, network := range n.MachineNetwork {
+		for j, network := range n.MachineNetwork {
 			if validate.DoCIDRsOverlap(&sn.IPNet, &network.CIDR.IPNet) {
-				allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), "service network must not overlap with any of the machine networks"))
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), fmt.Sprintf("service network must not overlap with machine network %d (%s)", j, network.CIDR.String())))
 			}
 		}
 		for j, snn := range n.ServiceNetwork[0:i] {
-			if validate.DoCIDRsOverlap(&sn.IPNet, &snn.IPNet) {
+			if canonicalIPNet(&sn.IPNet).String() == canonicalIPNet(&snn.IPNet).String() {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), fmt.Sprintf("service network is the same range as service network %d", j)))
+			} else if validate.DoCIDRsOverlap(&sn.IPNet, &snn.IPNet) {
 				allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceNetwork").Index(i), sn.String(), fmt.Sprintf("service network must not overlap with service network %d", j)))
 			}
 		}
@@ -292,6 +380,18 @@ func validateNetworking(n *types.Networking, fldPath *field.Path) field.ErrorLis
 	return allErrs
 }
 
+// canonicalIPNet returns the network address of n, with IPv4-mapped IPv6
+// networks (e.g. ::ffff:172.30.0.0/112) converted to their IPv4 form so that
+// the same range written in different forms compares equal.
+func canonicalIPNet(n *net.IPNet) *net.IPNet {
+	ones, bits := n.Mask.Size()
+	if ip4 := n.IP.To4(); ip4 != nil && bits == 8*net.IPv6len && ones >= 96 {
+		mask := net.CIDRMask(ones-96, 8*net.IPv4len)
+		return &net.IPNet{IP: ip4.Mask(mask), Mask: mask}
+	}
+	return &net.IPNet{IP: n.IP.Mask(n.Mask), Mask: n.Mask}
+}
+
 func validateNetworkingForPlatform(n *types.Networking, platform *types.Platform, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	switch {
@@ -333,14 +433,31 @@ func validateNetworkingForPlatform(n *types.Networking, platform *types.Platform
 	return allErrs
 }
 
+// warnLargeNetworks warns about IPv4 cluster and service networks that are far
+// larger than any cluster needs, since they are likely to overlap with other
+// networks in the environment.
+func warnLargeNetworks(n *types.Networking, fldPath *field.Path) {
+	warningMsgFmt := "%s: %s is unusually large, consider a network with a prefix of at least /%d"
+	for idx, cn := range n.ClusterNetwork {
+		if ones, bits := cn.CIDR.Mask.Size(); bits == 8*net.IPv4len && ones < largeIPv4NetworkPrefix {
+			logrus.Warnf(warningMsgFmt, fldPath.Child("clusterNetwork").Index(idx), cn.CIDR.String(), largeIPv4NetworkPrefix)
+		}
+	}
+	for idx, sn := range n.ServiceNetwork {
+		if ones, bits := sn.Mask.Size(); bits == 8*net.IPv4len && ones < largeIPv4NetworkPrefix {
+			logrus.Warnf(warningMsgFmt, fldPath.Child("serviceNetwork").Index(idx), sn.String(), largeIPv4NetworkPrefix)
+		}
+	}
+}
+
 func validateClusterNetwork(n *types.Networking, cn *types.ClusterNetworkEntry, idx int, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if err := validate.SubnetCIDR(&cn.CIDR.IPNet); err != nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.IPNet.String(), err.Error()))
 	}
-	for _, network := range n.MachineNetwork {
+	for i, network := range n.MachineNetwork {
 		if validate.DoCIDRsOverlap(&cn.CIDR.IPNet, &network.CIDR.IPNet) {
-			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.String(), "cluster network must not overlap with any of the machine networks"))
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidr"), cn.CIDR.String(), fmt.Sprintf("cluster network must not overlap with machine network %d (%s)", i, network.CIDR.String())))
 		}
 	}
 	for i, sn := range n.ServiceNetwork {
@@ -360,6 +477,8 @@ func validateClusterNetwork(n *types.Networking, cn *types.ClusterNetworkEntry,
 	if pluginsUsingHostPrefix.Has(n.NetworkType) || (cn.HostPrefix != 0) {
 		if ones, bits := cn.CIDR.Mask.Size(); cn.HostPrefix < int32(ones) {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostPrefix"), cn.HostPrefix, "cluster network host subnetwork prefix must not be larger size than CIDR "+cn.CIDR.String()))
+		} else if bits == 32 && cn.HostPrefix > 32 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostPrefix"), cn.HostPrefix, "cluster network host subnetwork prefix must not be larger than 32 for IPv4 networks"))
 		} else if bits == 128 && cn.HostPrefix != 64 {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("hostPrefix"), cn.HostPrefix, "cluster network host subnetwork prefix must be 64 for IPv6 networks"))
 		}
@@ -367,6 +486,140 @@ func validateClusterNetwork(n *types.Networking, cn *types.ClusterNetworkEntry,
 	return allErrs
 }
 
+// machineReplicas returns the total number of control plane and compute
+// replicas requested by the install config.
+func machineReplicas(c *types.InstallConfig) int64 {
+	var replicas int64
+	if c.ControlPlane != nil && c.ControlPlane.Replicas != nil {
+		replicas += *c.ControlPlane.Replicas
+	}
+	for _, pool := range c.Compute {
+		if pool.Replicas != nil {
+			replicas += *pool.Replicas
+		}
+	}
+	return replicas
+}
+
+// validateClusterNetworkCapacity checks that, for each IP family, the cluster
+// networks can be carved into at least one host subnet per node, plus
+// clusterNetworkHeadroomNodes for multi-node clusters. Each cluster network
+// provides 2^(hostPrefix - prefix) host subnets.
+func validateClusterNetworkCapacity(n *types.Networking, nodes int64, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !pluginsUsingHostPrefix.Has(n.NetworkType) {
+		return allErrs
+	}
+	capacity := map[bool]int64{}
+	cidrs := map[bool][]string{}
+	reported := map[bool]bool{}
+	for i, cn := range n.ClusterNetwork {
+		ones, bits := cn.CIDR.Mask.Size()
+		if cn.HostPrefix < int32(ones) || cn.HostPrefix > int32(bits) {
+			// reported by validateClusterNetwork
+			continue
+		}
+		isIPv6 := cn.CIDR.IP.To4() == nil
+		cidrs[isIPv6] = append(cidrs[isIPv6], cn.CIDR.String())
+		// single-node clusters only ever need one host subnetwork
+		if cn.HostPrefix == int32(ones) && nodes > 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("hostPrefix"), cn.HostPrefix, fmt.Sprintf("cluster network host subnetwork prefix equal to the prefix of CIDR %s allows only a single node", cn.CIDR.String())))
+			reported[isIPv6] = true
+		}
+		if subnetBits := int(cn.HostPrefix) - ones; subnetBits >= 62 || capacity[isIPv6] >= 1<<62 {
+			capacity[isIPv6] = 1 << 62
+		} else {
+			capacity[isIPv6] += 1 << subnetBits
+		}
+	}
+	required := nodes
+	if nodes > 1 {
+		required += clusterNetworkHeadroomNodes
+	}
+	for _, isIPv6 := range []bool{false, true} {
+		if len(cidrs[isIPv6]) > 0 && !reported[isIPv6] && capacity[isIPv6] < required {
+			allErrs = append(allErrs, field.Invalid(fldPath, strings.Join(cidrs[isIPv6], ", "), fmt.Sprintf("cluster network host subnetworks can address at most %d nodes, but at least %d are required for %d control plane and compute replicas and scale-out headroom", capacity[isIPv6], required, nodes)))
+		}
+	}
+	return allErrs
+}
+
+// validateServiceNetworkCapacity checks that each service network has enough
+// usable addresses for the core cluster services plus one per node.
+func validateServiceNetworkCapacity(n *types.Networking, nodes int64, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	required := serviceNetworkBaselineAddresses + nodes
+	for i, sn := range n.ServiceNetwork {
+		ones, bits := sn.Mask.Size()
+		if hostBits := bits - ones; hostBits < 62 {
+			// the network and broadcast addresses are not assignable
+			if usable := int64(1)<<hostBits - 2; usable < required {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i), sn.String(), fmt.Sprintf("service network has %d usable addresses, but at least %d are required for the core cluster services and %d nodes", usable, required, nodes)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// platformVIPs returns the API and ingress VIPs configured for the platform.
+func platformVIPs(p *types.Platform) []string {
+	var vips []string
+	switch {
+	case p.BareMetal != nil:
+		vips = []string{p.BareMetal.APIVIP, p.BareMetal.IngressVIP}
+	case p.Kubevirt != nil:
+		vips = []string{p.Kubevirt.APIVIP, p.Kubevirt.IngressVIP}
+	case p.OpenStack != nil:
+		vips = []string{p.OpenStack.APIVIP, p.OpenStack.IngressVIP}
+	case p.Ovirt != nil:
+		vips = []string{p.Ovirt.APIVIP, p.Ovirt.IngressVIP}
+	case p.VSphere != nil:
+		vips = []string{p.VSphere.APIVIP, p.VSphere.IngressVIP}
+	}
+	configured := []string{}
+	for _, vip := range vips {
+		if vip != "" {
+			configured = append(configured, vip)
+		}
+	}
+	return configured
+}
+
+// validateMachineNetworkCapacity checks that, for each IP family, the machine
+// networks have enough usable addresses for the nodes, the VIPs and the
+// infrastructure addresses.
+func validateMachineNetworkCapacity(n *types.Networking, p *types.Platform, nodes int64, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	required := map[bool]int64{
+		false: nodes + machineNetworkInfrastructureAddresses,
+		true:  nodes + machineNetworkInfrastructureAddresses,
+	}
+	for _, vip := range platformVIPs(p) {
+		if ip := net.ParseIP(vip); ip != nil {
+			required[ip.To4() == nil]++
+		}
+	}
+	capacity := map[bool]int64{}
+	cidrs := map[bool][]string{}
+	for _, mn := range n.MachineNetwork {
+		ones, bits := mn.CIDR.Mask.Size()
+		isIPv6 := mn.CIDR.IP.To4() == nil
+		cidrs[isIPv6] = append(cidrs[isIPv6], mn.CIDR.String())
+		if hostBits := bits - ones; hostBits >= 62 || capacity[isIPv6] >= 1<<62 {
+			capacity[isIPv6] = 1 << 62
+		} else if hostBits > 1 {
+			// the network and broadcast addresses are not assignable
+			capacity[isIPv6] += 1<<hostBits - 2
+		}
+	}
+	for _, isIPv6 := range []bool{false, true} {
+		if len(cidrs[isIPv6]) > 0 && capacity[isIPv6] < required[isIPv6] {
+			allErrs = append(allErrs, field.Invalid(fldPath, strings.Join(cidrs[isIPv6], ", "), fmt.Sprintf("machine network has %d usable addresses, but at least %d are required for %d nodes, the VIPs, the bootstrap machine and the gateway", capacity[isIPv6], required[isIPv6], nodes)))
+		}
+	}
+	return allErrs
+}
+
 func validateControlPlane(platform *types.Platform, pool *types.MachinePool, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if pool.Name != masterPoolName {